@@ -0,0 +1,108 @@
+package rotatelogs
+
+import "sync/atomic"
+
+// asyncItem is what gets sent over RotateLogs.asyncQueue. data is
+// a Write payload to hand to writeSync; flush, when set, is a
+// request from Flush to be closed once every item queued ahead of
+// it has been processed.
+type asyncItem struct {
+	data  []byte
+	flush chan struct{}
+}
+
+// writeAsync backs Write when WithAsyncBuffer is in use. It never
+// touches the file system itself: it copies p, so the caller's
+// buffer can be reused, and hands it off to asyncLoop.
+func (rl *RotateLogs) writeAsync(p []byte) (int, error) {
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	item := asyncItem{data: buf}
+
+	if !rl.asyncDropOldest {
+		rl.asyncQueue <- item
+		return len(p), nil
+	}
+
+	select {
+	case rl.asyncQueue <- item:
+		return len(p), nil
+	default:
+	}
+
+	// Buffer is full: make room by dropping the oldest entry, then
+	// try again. If another goroutine wins the race for the slot
+	// we just freed, drop this entry instead rather than blocking.
+	select {
+	case old := <-rl.asyncQueue:
+		atomic.AddInt64(&rl.droppedBytes, int64(len(old.data)))
+	default:
+	}
+
+	select {
+	case rl.asyncQueue <- item:
+	default:
+		atomic.AddInt64(&rl.droppedBytes, int64(len(buf)))
+	}
+
+	return len(p), nil
+}
+
+// asyncLoop is the single goroutine that drains asyncQueue,
+// calling writeSync for every entry in the order it was enqueued.
+func (rl *RotateLogs) asyncLoop() {
+	defer rl.asyncWG.Done()
+
+	for {
+		select {
+		case item := <-rl.asyncQueue:
+			rl.handleAsyncItem(item)
+		case <-rl.asyncDone:
+			rl.drainAsyncQueue()
+			return
+		}
+	}
+}
+
+// drainAsyncQueue processes whatever is left in asyncQueue without
+// blocking, so Close doesn't discard entries that were enqueued
+// before it was called.
+func (rl *RotateLogs) drainAsyncQueue() {
+	for {
+		select {
+		case item := <-rl.asyncQueue:
+			rl.handleAsyncItem(item)
+		default:
+			return
+		}
+	}
+}
+
+func (rl *RotateLogs) handleAsyncItem(item asyncItem) {
+	if item.flush != nil {
+		close(item.flush)
+		return
+	}
+	rl.writeSync(item.data)
+}
+
+// Flush blocks until every entry enqueued so far by the async
+// writer has been processed. It is a no-op when WithAsyncBuffer
+// isn't in use.
+func (rl *RotateLogs) Flush() error {
+	if rl.asyncQueue == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
+	rl.asyncQueue <- asyncItem{flush: done}
+	<-done
+	return nil
+}
+
+// DroppedBytes returns the number of bytes dropped because the
+// async buffer was full and WithAsyncDropOldest was set. It is
+// always 0 when WithAsyncBuffer isn't in use.
+func (rl *RotateLogs) DroppedBytes() int64 {
+	return atomic.LoadInt64(&rl.droppedBytes)
+}
@@ -0,0 +1,60 @@
+package rotatelogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAsyncBufferWritesAndFlushes(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "test.log")
+
+	rl := New(pattern, WithAsyncBuffer(4))
+	defer rl.Close()
+
+	if _, err := rl.Write([]byte("hello ")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if _, err := rl.Write([]byte("world")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+
+	if err := rl.Flush(); err != nil {
+		t.Fatalf("Flush: %s", err)
+	}
+
+	got, err := os.ReadFile(pattern)
+	if err != nil {
+		t.Fatalf("ReadFile: %s", err)
+	}
+	if string(got) != "hello world" {
+		t.Fatalf("expected %q, got %q", "hello world", got)
+	}
+}
+
+func TestAsyncBufferDropOldest(t *testing.T) {
+	// Exercise writeAsync directly against a queue with no drain
+	// goroutine running, so a full buffer is guaranteed rather than
+	// racing a background reader that might drain it first.
+	rl := &RotateLogs{
+		asyncDropOldest: true,
+		asyncQueue:      make(chan asyncItem, 1),
+	}
+
+	if _, err := rl.writeAsync([]byte("first")); err != nil {
+		t.Fatalf("writeAsync: %s", err)
+	}
+	if _, err := rl.writeAsync([]byte("second")); err != nil {
+		t.Fatalf("writeAsync: %s", err)
+	}
+
+	if got, want := rl.DroppedBytes(), int64(len("first")); got != want {
+		t.Fatalf("expected %d dropped bytes, got %d", want, got)
+	}
+
+	item := <-rl.asyncQueue
+	if string(item.data) != "second" {
+		t.Fatalf("expected the newest entry to survive, got %q", item.data)
+	}
+}
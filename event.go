@@ -0,0 +1,74 @@
+package rotatelogs
+
+// EventType identifies the kind of Event that occurred.
+type EventType int
+
+const (
+	// FileRotatedEventType is the type of FileRotatedEvent.
+	FileRotatedEventType EventType = iota
+	// FilePurgedEventType is the type of FilePurgedEvent.
+	FilePurgedEventType
+)
+
+// Event is implemented by every event that RotateLogs may
+// dispatch to a registered Handler.
+type Event interface {
+	Type() EventType
+}
+
+// FileRotatedEvent is dispatched whenever RotateLogs finishes
+// rotating to a new file.
+type FileRotatedEvent struct {
+	prev string
+	cur  string
+}
+
+// NewFileRotatedEvent creates a new FileRotatedEvent reporting a
+// rotation away from previous and onto current.
+func NewFileRotatedEvent(previous, current string) *FileRotatedEvent {
+	return &FileRotatedEvent{prev: previous, cur: current}
+}
+
+func (e *FileRotatedEvent) Type() EventType {
+	return FileRotatedEventType
+}
+
+// PreviousFile returns the name of the file that was being
+// written to before the rotation.
+func (e *FileRotatedEvent) PreviousFile() string {
+	return e.prev
+}
+
+// CurrentFile returns the name of the file being written to
+// after the rotation.
+func (e *FileRotatedEvent) CurrentFile() string {
+	return e.cur
+}
+
+// FilePurgedEvent is dispatched once rotate() finishes removing
+// the files its RotateRule considered outdated.
+type FilePurgedEvent struct {
+	paths []string
+}
+
+// NewFilePurgedEvent creates a new FilePurgedEvent listing the
+// paths that were removed.
+func NewFilePurgedEvent(paths []string) *FilePurgedEvent {
+	return &FilePurgedEvent{paths: paths}
+}
+
+func (e *FilePurgedEvent) Type() EventType {
+	return FilePurgedEventType
+}
+
+// Paths returns the list of files that were removed.
+func (e *FilePurgedEvent) Paths() []string {
+	return e.paths
+}
+
+// Handler is the interface that must be implemented by anything
+// passed to WithHandler. Handle is called once per dispatched
+// Event, on its own goroutine.
+type Handler interface {
+	Handle(Event)
+}
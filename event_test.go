@@ -0,0 +1,101 @@
+package rotatelogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// handlerFunc adapts a plain function to the Handler interface,
+// the same way http.HandlerFunc adapts a function to http.Handler.
+type handlerFunc func(Event)
+
+func (f handlerFunc) Handle(ev Event) {
+	f(ev)
+}
+
+func TestHandlerReceivesFileRotatedEvent(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "test.log")
+
+	events := make(chan Event, 1)
+	rl := New(pattern,
+		WithRotateRule(NewSizeRotateRule(pattern, 5)),
+		WithHandler(handlerFunc(func(ev Event) { events <- ev })),
+	)
+	defer rl.Close()
+
+	// Mirrors the size-rotation sequence in rotatelogs_test.go: the
+	// first two writes stay on the bare pattern, the second pushes
+	// the file past maxBytes, and the third rotates.
+	if _, err := rl.Write([]byte("ab")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if _, err := rl.Write([]byte("abcdef")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if _, err := rl.Write([]byte("x")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+
+	select {
+	case ev := <-events:
+		fre, ok := ev.(*FileRotatedEvent)
+		if !ok {
+			t.Fatalf("expected *FileRotatedEvent, got %T", ev)
+		}
+		if fre.PreviousFile() != pattern {
+			t.Fatalf("expected previous file %q, got %q", pattern, fre.PreviousFile())
+		}
+		if want := pattern + ".1"; fre.CurrentFile() != want {
+			t.Fatalf("expected current file %q, got %q", want, fre.CurrentFile())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for FileRotatedEvent")
+	}
+}
+
+func TestHandlerReceivesFilePurgedEvent(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "test.log.%Y%m%d")
+
+	oldFile := filepath.Join(dir, "test.log.20200101")
+	if err := os.WriteFile(oldFile, []byte("x"), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	oldTime := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(oldFile, oldTime, oldTime); err != nil {
+		t.Fatalf("Chtimes: %s", err)
+	}
+
+	events := make(chan Event, 1)
+	rl := New(pattern,
+		WithMaxAge(24*time.Hour),
+		WithHandler(handlerFunc(func(ev Event) { events <- ev })),
+	)
+	defer rl.Close()
+
+	if _, err := rl.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+
+	select {
+	case ev := <-events:
+		fpe, ok := ev.(*FilePurgedEvent)
+		if !ok {
+			t.Fatalf("expected *FilePurgedEvent, got %T", ev)
+		}
+		found := false
+		for _, path := range fpe.Paths() {
+			if path == oldFile {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("expected %q among the purged paths, got %v", oldFile, fpe.Paths())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for FilePurgedEvent")
+	}
+}
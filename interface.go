@@ -0,0 +1,61 @@
+package rotatelogs
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Clock is the interface used by the RotateLogs
+// object to determine the current time
+type Clock interface {
+	Now() time.Time
+}
+
+type clockFn func() time.Time
+
+// UTC is an object satisfying the Clock interface, which
+// returns the current time in UTC
+var UTC = clockFn(func() time.Time { return time.Now().UTC() })
+
+// Local is an object satisfying the Clock interface, which
+// returns the current time in the local timezone
+var Local = clockFn(time.Now)
+
+// RotateLogs represents a log file that gets
+// automatically rotated as you write to it.
+type RotateLogs struct {
+	asyncBufSize    int
+	asyncCloseOnce  sync.Once
+	asyncDone       chan struct{}
+	asyncDropOldest bool
+	asyncQueue      chan asyncItem
+	asyncWG         sync.WaitGroup
+	clock           Clock
+	compress        bool
+	compressing     sync.Map
+	curFn           string
+	droppedBytes    int64
+	eventHandler    Handler
+	globPattern     string
+	linkName        string
+	maxAge          time.Duration
+	maxBackups      int
+	mutex           sync.RWMutex
+	outFh           *os.File
+	pattern         string
+	rotateRule      RotateRule
+	rotationOffset  time.Duration
+	rotationTime    time.Duration
+	written         int64
+}
+
+// Option is used to pass optional arguments to
+// the RotateLogs constructor
+type Option interface {
+	Configure(*RotateLogs) error
+}
+
+// OptionFn is a type of Option that is created by using
+// a closure
+type OptionFn func(*RotateLogs) error
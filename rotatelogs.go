@@ -5,16 +5,16 @@
 package rotatelogs
 
 import (
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
-
-	"bitbucket.org/tebeka/strftime"
 )
 
 func (c clockFn) Now() time.Time {
@@ -68,6 +68,101 @@ func WithRotationTime(d time.Duration) Option {
 	})
 }
 
+// WithRotationOffset creates a new Option that shifts rotation
+// boundaries by d, so that rotation happens relative to wall-clock
+// time in a given timezone rather than at UTC epoch multiples of
+// the rotation time. For example, passing 5*time.Hour+30*time.Minute
+// together with WithRotationTime(24*time.Hour) rotates at local
+// midnight in a +05:30 timezone instead of at 05:30 local time
+// (UTC midnight). It also applies to sub-hour rotation times, so a
+// WithRotationTime(10*time.Minute) can be made to land on the
+// wall-clock :00, :10, :20, ... boundaries regardless of when the
+// process started.
+//
+// d must be the target zone's own UTC offset; WithClock(Local) by
+// itself does not infer it; the rotation boundary calculation does
+// not look at the clock's time.Location, so rotation stays on UTC
+// boundaries until a matching d is passed here.
+func WithRotationOffset(d time.Duration) Option {
+	return OptionFn(func(rl *RotateLogs) error {
+		rl.rotationOffset = d
+		return nil
+	})
+}
+
+// WithCompress creates a new Option that tells RotateLogs
+// to gzip-compress rotated files that are still within
+// maxAge instead of leaving them as plain text. The file
+// currently being written to is never compressed.
+func WithCompress(compress bool) Option {
+	return OptionFn(func(rl *RotateLogs) error {
+		rl.compress = compress
+		return nil
+	})
+}
+
+// WithRotateRule creates a new Option that sets the RotateRule
+// used to decide when to rotate, what the next file name should
+// be, and which files are eligible for purging. When this option
+// isn't used, a TimeRotateRule built from the pattern passed to
+// New plus WithRotationTime/WithMaxAge is used, preserving the
+// historical strftime-based rotation behavior.
+func WithRotateRule(r RotateRule) Option {
+	return OptionFn(func(rl *RotateLogs) error {
+		rl.rotateRule = r
+		return nil
+	})
+}
+
+// WithHandler creates a new Option that sets the Handler that
+// RotateLogs dispatches Events to: a FileRotatedEvent whenever a
+// rotation completes, and a FilePurgedEvent once the outdated
+// files from a rotation have been removed. Handle is always
+// called on its own goroutine.
+func WithHandler(h Handler) Option {
+	return OptionFn(func(rl *RotateLogs) error {
+		rl.eventHandler = h
+		return nil
+	})
+}
+
+// WithMaxBackups creates a new Option that wraps the configured
+// RotateRule so that only the newest n files matched by the glob
+// pattern are retained, regardless of maxAge.
+func WithMaxBackups(n int) Option {
+	return OptionFn(func(rl *RotateLogs) error {
+		rl.maxBackups = n
+		return nil
+	})
+}
+
+// WithAsyncBuffer creates a new Option that makes Write enqueue a
+// copy of p onto a buffered channel of size size and return
+// immediately, while a single background goroutine performs the
+// actual filename resolution, rotation and disk I/O. Call Flush
+// to wait for the queue to drain, and Close to stop the
+// background goroutine. By default a full buffer blocks the
+// caller; pair with WithAsyncDropOldest to drop old entries
+// instead.
+func WithAsyncBuffer(size int) Option {
+	return OptionFn(func(rl *RotateLogs) error {
+		rl.asyncBufSize = size
+		return nil
+	})
+}
+
+// WithAsyncDropOldest creates a new Option that, when combined
+// with WithAsyncBuffer, makes Write drop the oldest queued entry
+// instead of blocking once the async buffer is full. Bytes
+// dropped this way are counted and can be read back via
+// DroppedBytes.
+func WithAsyncDropOldest(dropOldest bool) Option {
+	return OptionFn(func(rl *RotateLogs) error {
+		rl.asyncDropOldest = dropOldest
+		return nil
+	})
+}
+
 // New creates a new RotateLogs object. A log filename pattern
 // must be passed. Optional `Option` parameters may be passed
 func New(pattern string, options ...Option) *RotateLogs {
@@ -85,39 +180,60 @@ func New(pattern string, options ...Option) *RotateLogs {
 		opt.Configure(&rl)
 	}
 
-	return &rl
-}
+	if rl.rotateRule == nil {
+		rl.rotateRule = NewTimeRotateRule(rl.pattern, rl.rotationTime, rl.maxAge, rl.rotationOffset, rl.clock)
+	}
+	if rl.maxBackups > 0 {
+		rl.rotateRule = NewCountRotateRule(rl.rotateRule, rl.maxBackups)
+	}
 
-func (rl *RotateLogs) genFilename() (string, error) {
-	now := rl.clock.Now()
-	diff := time.Duration(now.UnixNano()) % rl.rotationTime
-	t := now.Add(time.Duration(-1 * diff))
-	str, err := strftime.Format(rl.pattern, t)
-	if err != nil {
-		return "", err
+	if rl.asyncBufSize > 0 {
+		rl.asyncQueue = make(chan asyncItem, rl.asyncBufSize)
+		rl.asyncDone = make(chan struct{})
+		rl.asyncWG.Add(1)
+		go rl.asyncLoop()
 	}
-	return str, err
+
+	return &rl
 }
 
 // Write satisfies the io.Writer interface. It writes to the
 // appropriate file handle that is currently being used.
 // If we have reached rotation time, the target file gets
 // automatically rotated, and also purged if necessary.
+//
+// When WithAsyncBuffer is used, Write enqueues p and returns
+// immediately instead of doing the rotation and disk I/O inline;
+// see writeAsync.
 func (rl *RotateLogs) Write(p []byte) (n int, err error) {
+	if rl.asyncQueue != nil {
+		return rl.writeAsync(p)
+	}
+	return rl.writeSync(p)
+}
+
+// writeSync performs the synchronous rotation and disk I/O that
+// backs Write, both when WithAsyncBuffer isn't used and from the
+// single background goroutine when it is.
+func (rl *RotateLogs) writeSync(p []byte) (n int, err error) {
 	// Guard against concurrent writes
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
+	now := rl.clock.Now()
+
 	// This filename contains the name of the "NEW" filename
 	// to log to, which may be newer than rl.currentFilename
-
-	filename, err := rl.genFilename()
-	if err != nil {
-		return 0, err
+	filename := rl.rotateRule.NextFileName(now)
+	bootstrap := rl.outFh == nil
+	rotating := !bootstrap && (filename != rl.curFn || rl.rotateRule.ShallRotate(rl.written))
+	if rotating {
+		rl.rotateRule.MarkRotated()
+		filename = rl.rotateRule.NextFileName(now)
 	}
 
 	var out *os.File
-	if filename == rl.curFn { // Match!
+	if !bootstrap && !rotating {
 		out = rl.outFh // use old one
 	}
 
@@ -143,17 +259,21 @@ func (rl *RotateLogs) Write(p []byte) (n int, err error) {
 
 		out = fh
 		if isNew {
+			rl.written = 0
 			rl.rotate(filename)
 		}
 	}
 
 	n, err = out.Write(p)
+	rl.written += int64(n)
 
 	if rl.outFh == nil {
 		rl.outFh = out
 	} else if isNew {
+		prevFn := rl.curFn
 		rl.outFh.Close()
 		rl.outFh = out
+		rl.dispatchEvent(NewFileRotatedEvent(prevFn, filename))
 	}
 	rl.curFn = filename
 
@@ -217,53 +337,124 @@ func (rl *RotateLogs) rotate(filename string) error {
 		}
 	}
 
-	if rl.maxAge <= 0 {
-		return errors.New("maxAge not set, not rotating")
+	if rl.compress {
+		rl.compressStaleFiles(filename)
+	}
+
+	toUnlink := rl.rotateRule.OutdatedFiles(rl.globPattern)
+	if len(toUnlink) <= 0 {
+		return errors.New("nothing to unlink")
 	}
 
+	guard.Enable()
+	go func() {
+		// unlink files on a separate goroutine
+		for _, path := range toUnlink {
+			os.Remove(path)
+		}
+		rl.dispatchEvent(NewFilePurgedEvent(toUnlink))
+	}()
+
+	return nil
+}
+
+// dispatchEvent hands ev to the registered Handler, if any, on
+// its own goroutine so that Write and rotate never block on it.
+func (rl *RotateLogs) dispatchEvent(ev Event) {
+	if rl.eventHandler == nil {
+		return
+	}
+	go rl.eventHandler.Handle(ev)
+}
+
+// compressStaleFiles gzip-compresses the files matched by the glob
+// pattern that the current RotateRule does not (yet) consider
+// outdated, skipping the file currently being written to and
+// anything already compressed. rotate() re-scans the glob on
+// every rotation, so a file already being compressed by an
+// earlier call is skipped rather than handed to a second,
+// concurrent compressFile goroutine.
+func (rl *RotateLogs) compressStaleFiles(filename string) {
 	matches, err := filepath.Glob(rl.globPattern)
 	if err != nil {
-		return err
+		return
+	}
+
+	outdated := make(map[string]struct{})
+	for _, path := range rl.rotateRule.OutdatedFiles(rl.globPattern) {
+		outdated[path] = struct{}{}
 	}
 
-	cutoff := rl.clock.Now().Add(-1 * rl.maxAge)
-	var toUnlink []string
 	for _, path := range matches {
-		// Ignore lock files
-		if strings.HasSuffix(path, "_lock") || strings.HasSuffix(path, "_symlink") {
+		if strings.HasSuffix(path, "_lock") || strings.HasSuffix(path, "_symlink") || strings.HasSuffix(path, ".gz") {
 			continue
 		}
-
-		fi, err := os.Stat(path)
-		if err != nil {
+		if path == filename {
 			continue
 		}
-
-		if fi.ModTime().After(cutoff) {
+		if _, ok := outdated[path]; ok {
+			continue
+		}
+		if _, alreadyCompressing := rl.compressing.LoadOrStore(path, struct{}{}); alreadyCompressing {
 			continue
 		}
-		toUnlink = append(toUnlink, path)
+		go func(path string) {
+			defer rl.compressing.Delete(path)
+			compressFile(path)
+		}(path)
 	}
+}
 
-	if len(toUnlink) <= 0 {
-		return errors.New("nothing to unlink")
+// compressFile gzips the file at path into path+".gz" and removes
+// the original once the compressed copy has been flushed to disk.
+// It is run in its own goroutine and has no return value to report
+// to, so failures simply leave the original file in place.
+func compressFile(path string) {
+	src, err := os.Open(path)
+	if err != nil {
+		return
 	}
+	defer src.Close()
 
-	guard.Enable()
-	go func() {
-		// unlink files on a separate goroutine
-		for _, path := range toUnlink {
-			os.Remove(path)
-		}
-	}()
+	gzPath := path + ".gz"
+	dst, err := os.OpenFile(gzPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return
+	}
+	defer dst.Close()
 
-	return nil
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		os.Remove(gzPath)
+		return
+	}
+	if err := gz.Close(); err != nil {
+		os.Remove(gzPath)
+		return
+	}
+	if err := dst.Sync(); err != nil {
+		return
+	}
+
+	os.Remove(path)
 }
 
 // Close satisfies the io.Closer interface. You must
 // call this method if you performed any writes to
 // the object.
+//
+// If WithAsyncBuffer is in use, Close first stops the background
+// goroutine, draining whatever is left in the queue before it
+// exits.
 func (rl *RotateLogs) Close() error {
+	if rl.asyncQueue != nil {
+		rl.asyncCloseOnce.Do(func() {
+			close(rl.asyncDone)
+		})
+		rl.asyncWG.Wait()
+	}
+
 	rl.mutex.Lock()
 	defer rl.mutex.Unlock()
 
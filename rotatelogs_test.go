@@ -0,0 +1,43 @@
+package rotatelogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteWithSizeRotateRuleKeepsBaseNameUntilRotation(t *testing.T) {
+	dir := t.TempDir()
+	pattern := filepath.Join(dir, "test.log")
+
+	rl := New(pattern, WithRotateRule(NewSizeRotateRule(pattern, 5)))
+	defer rl.Close()
+
+	if _, err := rl.Write([]byte("ab")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if got := rl.CurrentFileName(); got != pattern {
+		t.Fatalf("expected the first write to land on the bare pattern %q, got %q", pattern, got)
+	}
+	if _, err := os.Stat(pattern + ".1"); err == nil {
+		t.Fatalf("did not expect %s.1 to exist before any size-triggered rotation", pattern)
+	}
+
+	// This write still lands on the original file: ShallRotate looks
+	// at the bytes written to it so far (2, below maxBytes) before
+	// it runs, so the file only crosses maxBytes once it's been written.
+	if _, err := rl.Write([]byte("abcdef")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if got := rl.CurrentFileName(); got != pattern {
+		t.Fatalf("expected %q to still be the bare pattern, got %q", pattern, got)
+	}
+
+	// Now that the file is over maxBytes, this write rotates.
+	if _, err := rl.Write([]byte("x")); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+	if got := rl.CurrentFileName(); got != pattern+".1" {
+		t.Fatalf("expected a rotation to %s.1, got %q", pattern, got)
+	}
+}
@@ -0,0 +1,214 @@
+package rotatelogs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"bitbucket.org/tebeka/strftime"
+)
+
+// RotateRule decides when a RotateLogs should rotate to a new
+// file, what that file should be named, and which of the files
+// matched by the glob pattern are no longer needed. Implementing
+// this interface lets RotateLogs be driven by something other
+// than the default strftime/time-based rotation, e.g. rotating
+// once a file grows past a certain size.
+type RotateRule interface {
+	// ShallRotate reports whether the file currently being
+	// written to should be rotated, given the number of bytes
+	// that have been written to it so far.
+	ShallRotate(written int64) bool
+
+	// NextFileName returns the name of the file that should be
+	// written to from this point on.
+	NextFileName(now time.Time) string
+
+	// OutdatedFiles returns, out of the files matched by glob,
+	// the ones this rule considers eligible for purging.
+	OutdatedFiles(glob string) []string
+
+	// MarkRotated is called right after a rotation has taken
+	// place, so the rule can update any internal bookkeeping.
+	MarkRotated()
+}
+
+// TimeRotateRule is the default RotateRule used by RotateLogs. It
+// rotates whenever the strftime pattern formatted for the current
+// time differs from the file currently being written to, and
+// purges files whose mtime is older than maxAge.
+type TimeRotateRule struct {
+	clock        Clock
+	maxAge       time.Duration
+	offset       time.Duration
+	pattern      string
+	rotationTime time.Duration
+}
+
+// NewTimeRotateRule creates a TimeRotateRule that formats pattern
+// using strftime, rotating every rotationTime and purging files
+// older than maxAge. A maxAge of 0 or less disables purging.
+// offset shifts the rotation boundaries away from UTC epoch
+// multiples of rotationTime; passing a zone's UTC offset makes them
+// land on that zone's wall-clock time. Pass 0 to keep the historical
+// behavior. clock is only used for the maxAge cutoff: its
+// time.Location plays no part in where the boundaries fall.
+func NewTimeRotateRule(pattern string, rotationTime, maxAge, offset time.Duration, clock Clock) *TimeRotateRule {
+	return &TimeRotateRule{
+		clock:        clock,
+		maxAge:       maxAge,
+		offset:       offset,
+		pattern:      pattern,
+		rotationTime: rotationTime,
+	}
+}
+
+func (r *TimeRotateRule) NextFileName(now time.Time) string {
+	diff := (time.Duration(now.UnixNano()) + r.offset) % r.rotationTime
+	t := now.Add(-diff)
+	str, err := strftime.Format(r.pattern, t)
+	if err != nil {
+		return r.pattern
+	}
+	return str
+}
+
+// ShallRotate ignores written, since time-based rotation only
+// cares about whether the formatted file name has changed.
+func (r *TimeRotateRule) ShallRotate(written int64) bool {
+	return false
+}
+
+func (r *TimeRotateRule) MarkRotated() {}
+
+func (r *TimeRotateRule) OutdatedFiles(glob string) []string {
+	if r.maxAge <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(glob)
+	if err != nil {
+		return nil
+	}
+	if gzMatches, err := filepath.Glob(glob + ".gz"); err == nil {
+		matches = append(matches, gzMatches...)
+	}
+
+	cutoff := r.clock.Now().Add(-1 * r.maxAge)
+	var outdated []string
+	for _, path := range matches {
+		if strings.HasSuffix(path, "_lock") || strings.HasSuffix(path, "_symlink") {
+			continue
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		if fi.ModTime().After(cutoff) {
+			continue
+		}
+		outdated = append(outdated, path)
+	}
+	return outdated
+}
+
+// SizeRotateRule is a RotateRule that rotates the file currently
+// being written to once it exceeds maxBytes, naming each backup
+// by appending a monotonically increasing counter to pattern.
+type SizeRotateRule struct {
+	maxBytes int64
+	pattern  string
+
+	mutex   sync.Mutex
+	counter int
+}
+
+// NewSizeRotateRule creates a SizeRotateRule that rotates pattern
+// once the current file has received more than maxBytes bytes.
+func NewSizeRotateRule(pattern string, maxBytes int64) *SizeRotateRule {
+	return &SizeRotateRule{
+		maxBytes: maxBytes,
+		pattern:  pattern,
+	}
+}
+
+func (r *SizeRotateRule) ShallRotate(written int64) bool {
+	return written > r.maxBytes
+}
+
+func (r *SizeRotateRule) NextFileName(_ time.Time) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.counter == 0 {
+		return r.pattern
+	}
+	return fmt.Sprintf("%s.%d", r.pattern, r.counter)
+}
+
+func (r *SizeRotateRule) MarkRotated() {
+	r.mutex.Lock()
+	r.counter++
+	r.mutex.Unlock()
+}
+
+// OutdatedFiles never reports any files as outdated; pair
+// SizeRotateRule with WithMaxBackups to bound how many backups
+// are kept.
+func (r *SizeRotateRule) OutdatedFiles(_ string) []string {
+	return nil
+}
+
+// CountRotateRule wraps another RotateRule, keeping its rotation
+// behavior intact but replacing its purge policy: only the newest
+// maxBackups files matched by the glob pattern are retained,
+// regardless of maxAge.
+type CountRotateRule struct {
+	RotateRule
+	maxBackups int
+}
+
+// NewCountRotateRule wraps rule so that only the newest
+// maxBackups rotated files are retained.
+func NewCountRotateRule(rule RotateRule, maxBackups int) *CountRotateRule {
+	return &CountRotateRule{RotateRule: rule, maxBackups: maxBackups}
+}
+
+func (r *CountRotateRule) OutdatedFiles(glob string) []string {
+	matches, err := filepath.Glob(glob)
+	if err != nil {
+		return nil
+	}
+	if gzMatches, err := filepath.Glob(glob + ".gz"); err == nil {
+		matches = append(matches, gzMatches...)
+	}
+
+	var files []string
+	for _, path := range matches {
+		if strings.HasSuffix(path, "_lock") || strings.HasSuffix(path, "_symlink") {
+			continue
+		}
+		files = append(files, path)
+	}
+
+	if len(files) <= r.maxBackups {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		fi, erri := os.Stat(files[i])
+		fj, errj := os.Stat(files[j])
+		if erri != nil || errj != nil {
+			return false
+		}
+		return fi.ModTime().Before(fj.ModTime())
+	})
+
+	return files[:len(files)-r.maxBackups]
+}
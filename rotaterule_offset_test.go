@@ -0,0 +1,38 @@
+package rotatelogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTimeRotateRuleNextFileName(t *testing.T) {
+	pattern := filepath.Join(os.TempDir(), "rotatelogs_test.%Y-%m-%d")
+
+	t.Run("zero offset rotates at UTC day boundaries", func(t *testing.T) {
+		r := NewTimeRotateRule(pattern, 24*time.Hour, 0, 0, UTC)
+		before := time.Date(2026, 7, 25, 23, 59, 59, 0, time.UTC)
+		after := time.Date(2026, 7, 26, 0, 0, 0, 0, time.UTC)
+		if r.NextFileName(before) == r.NextFileName(after) {
+			t.Fatalf("expected a different file name across the UTC day boundary")
+		}
+	})
+
+	t.Run("offset aligns rotation to local midnight", func(t *testing.T) {
+		ist := time.FixedZone("IST", 5*3600+1800)
+		r := NewTimeRotateRule(pattern, 24*time.Hour, 0, 5*time.Hour+30*time.Minute, Local)
+
+		midnight := time.Date(2026, 7, 26, 0, 0, 0, 0, ist)
+		before := r.NextFileName(midnight.Add(-time.Second))
+		after := r.NextFileName(midnight)
+		if before == after {
+			t.Fatalf("expected rotation at IST midnight, got the same file name %q for both sides", before)
+		}
+
+		justAfter := r.NextFileName(midnight.Add(time.Second))
+		if after != justAfter {
+			t.Fatalf("expected the same file name shortly after IST midnight: %q vs %q", after, justAfter)
+		}
+	})
+}
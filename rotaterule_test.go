@@ -0,0 +1,62 @@
+package rotatelogs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSizeRotateRule(t *testing.T) {
+	pattern := filepath.Join(os.TempDir(), "rotatelogs_test.log")
+	r := NewSizeRotateRule(pattern, 100)
+
+	if got := r.NextFileName(time.Time{}); got != pattern {
+		t.Fatalf("expected the bare pattern before any rotation, got %q", got)
+	}
+	if r.ShallRotate(50) {
+		t.Fatalf("did not expect a rotation below maxBytes")
+	}
+	if !r.ShallRotate(150) {
+		t.Fatalf("expected a rotation once written exceeds maxBytes")
+	}
+
+	r.MarkRotated()
+	if got, want := r.NextFileName(time.Time{}), pattern+".1"; got != want {
+		t.Fatalf("expected %q after the first rotation, got %q", want, got)
+	}
+
+	r.MarkRotated()
+	if got, want := r.NextFileName(time.Time{}), pattern+".2"; got != want {
+		t.Fatalf("expected %q after the second rotation, got %q", want, got)
+	}
+}
+
+func TestCountRotateRuleOutdatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	glob := filepath.Join(dir, "access.log.*")
+
+	var paths []string
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(dir, "access.log."+string(rune('a'+i)))
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+		modTime := time.Now().Add(time.Duration(i) * time.Second)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Chtimes: %s", err)
+		}
+		paths = append(paths, path)
+	}
+
+	rule := NewCountRotateRule(NewTimeRotateRule(glob, 24*time.Hour, 0, 0, Local), 2)
+	outdated := rule.OutdatedFiles(glob)
+	if len(outdated) != 3 {
+		t.Fatalf("expected 3 outdated files, got %d: %v", len(outdated), outdated)
+	}
+	for _, path := range outdated {
+		if path == paths[3] || path == paths[4] {
+			t.Fatalf("did not expect the 2 newest files to be outdated, got %q", path)
+		}
+	}
+}